@@ -0,0 +1,231 @@
+package decor
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/VividCortex/ewma"
+)
+
+// MovingAverage is the interface that computes a moving average over a
+// stream of values. It is implemented by github.com/VividCortex/ewma's
+// MovingAverage, as well as by NewMedianMovingAverage and
+// NewThreadSafeMovingAverage below.
+type MovingAverage = ewma.MovingAverage
+
+// TimeMovingAverage is implemented by MovingAverage types that want the
+// real wall-clock time of each sample rather than an assumed fixed
+// sample age. NextAmount favors AddTime over Add whenever the configured
+// average implements this interface, so a time-aware average (see
+// NewVariableEWMA) gets real deltas instead of just the caller-supplied
+// work duration.
+type TimeMovingAverage interface {
+	MovingAverage
+	AddTime(sample float64, now time.Time)
+}
+
+// feedAverage computes a speed sample from n/workDuration (the last
+// element of wdd) and feeds it into average, preferring AddTime over Add
+// whenever average implements TimeMovingAverage so it gets a real
+// wall-clock delta. Shared by movingAverageSpeed.NextAmount and
+// SpeedSource.NextAmount.
+func feedAverage(average MovingAverage, n int64, wdd ...time.Duration) {
+	var workDuration time.Duration
+	for _, wd := range wdd {
+		workDuration = wd
+	}
+	speed := float64(n) / workDuration.Seconds() / 1000
+	if math.IsInf(speed, 0) || math.IsNaN(speed) {
+		return
+	}
+	if tma, ok := average.(TimeMovingAverage); ok {
+		tma.AddTime(speed, time.Now())
+	} else {
+		average.Add(speed)
+	}
+}
+
+type medianSample struct {
+	value float64
+	inLow bool
+	index int
+}
+
+// lowHeap is a max-heap holding the lower half of the current window.
+type lowHeap []*medianSample
+
+func (h lowHeap) Len() int           { return len(h) }
+func (h lowHeap) Less(i, j int) bool { return h[i].value > h[j].value }
+func (h lowHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *lowHeap) Push(x interface{}) {
+	s := x.(*medianSample)
+	s.index = len(*h)
+	*h = append(*h, s)
+}
+
+func (h *lowHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	s := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return s
+}
+
+// highHeap is a min-heap holding the upper half of the current window.
+type highHeap []*medianSample
+
+func (h highHeap) Len() int           { return len(h) }
+func (h highHeap) Less(i, j int) bool { return h[i].value < h[j].value }
+func (h highHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *highHeap) Push(x interface{}) {
+	s := x.(*medianSample)
+	s.index = len(*h)
+	*h = append(*h, s)
+}
+
+func (h *highHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	s := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return s
+}
+
+// medianMovingAverage is a MovingAverage implementation that reports the
+// median of the last window samples, kept in a ring buffer backed by two
+// heaps.
+type medianMovingAverage struct {
+	window int
+	ring   []*medianSample
+	pos    int
+	low    lowHeap
+	high   highHeap
+}
+
+// NewMedianMovingAverage returns a MovingAverage that reports the median
+// of the last window samples, recomputed in O(log window) per Add.
+func NewMedianMovingAverage(window int) MovingAverage {
+	if window <= 0 {
+		window = 1
+	}
+	return &medianMovingAverage{
+		window: window,
+		ring:   make([]*medianSample, window),
+	}
+}
+
+func (m *medianMovingAverage) Add(value float64) {
+	if old := m.ring[m.pos]; old != nil {
+		m.removeSample(old)
+	}
+	s := &medianSample{value: value}
+	m.ring[m.pos] = s
+	m.insertSample(s)
+	m.pos = (m.pos + 1) % m.window
+	m.rebalance()
+}
+
+func (m *medianMovingAverage) Value() float64 {
+	switch {
+	case m.low.Len() == 0 && m.high.Len() == 0:
+		return 0
+	case m.low.Len() > m.high.Len():
+		return m.low[0].value
+	case m.low.Len() < m.high.Len():
+		return m.high[0].value
+	default:
+		return (m.low[0].value + m.high[0].value) / 2
+	}
+}
+
+// Set clears the window and seeds it with a single value.
+func (m *medianMovingAverage) Set(value float64) {
+	for i := range m.ring {
+		m.ring[i] = nil
+	}
+	m.low, m.high = nil, nil
+	m.pos = 0
+	m.Add(value)
+}
+
+func (m *medianMovingAverage) insertSample(s *medianSample) {
+	switch {
+	case m.low.Len() > 0 && s.value <= m.low[0].value:
+		s.inLow = true
+		heap.Push(&m.low, s)
+	case m.high.Len() > 0 && s.value >= m.high[0].value:
+		s.inLow = false
+		heap.Push(&m.high, s)
+	case m.low.Len() <= m.high.Len():
+		s.inLow = true
+		heap.Push(&m.low, s)
+	default:
+		s.inLow = false
+		heap.Push(&m.high, s)
+	}
+}
+
+func (m *medianMovingAverage) removeSample(s *medianSample) {
+	if s.inLow {
+		heap.Remove(&m.low, s.index)
+	} else {
+		heap.Remove(&m.high, s.index)
+	}
+}
+
+func (m *medianMovingAverage) rebalance() {
+	for m.low.Len() > m.high.Len()+1 {
+		s := heap.Pop(&m.low).(*medianSample)
+		s.inLow = false
+		heap.Push(&m.high, s)
+	}
+	for m.high.Len() > m.low.Len()+1 {
+		s := heap.Pop(&m.high).(*medianSample)
+		s.inLow = true
+		heap.Push(&m.low, s)
+	}
+}
+
+// threadSafeMovingAverage guards a MovingAverage with a mutex, so it can
+// be shared by decorators fed from different goroutines (see SpeedSource).
+type threadSafeMovingAverage struct {
+	mu sync.Mutex
+	MovingAverage
+}
+
+// NewThreadSafeMovingAverage wraps ma so Add, Value and Set can be called
+// concurrently.
+func NewThreadSafeMovingAverage(ma MovingAverage) MovingAverage {
+	return &threadSafeMovingAverage{MovingAverage: ma}
+}
+
+func (ts *threadSafeMovingAverage) Add(value float64) {
+	ts.mu.Lock()
+	ts.MovingAverage.Add(value)
+	ts.mu.Unlock()
+}
+
+func (ts *threadSafeMovingAverage) Value() float64 {
+	ts.mu.Lock()
+	v := ts.MovingAverage.Value()
+	ts.mu.Unlock()
+	return v
+}
+
+func (ts *threadSafeMovingAverage) Set(value float64) {
+	ts.mu.Lock()
+	ts.MovingAverage.Set(value)
+	ts.mu.Unlock()
+}
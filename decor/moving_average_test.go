@@ -0,0 +1,77 @@
+package decor
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func referenceMedian(window []float64) float64 {
+	if len(window) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), window...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func TestMedianMovingAverage(t *testing.T) {
+	cases := []struct {
+		name   string
+		window int
+		values []float64
+	}{
+		{"odd window", 3, []float64{5, 1, 4, 2, 8, 3, 9, 0}},
+		{"even window", 4, []float64{5, 1, 4, 2, 8, 3, 9, 0, 7}},
+		{"window larger than samples", 5, []float64{3, 1, 2}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ma := NewMedianMovingAverage(tc.window)
+			var ring []float64
+			for _, v := range tc.values {
+				ma.Add(v)
+				ring = append(ring, v)
+				if len(ring) > tc.window {
+					ring = ring[len(ring)-tc.window:]
+				}
+				want := referenceMedian(ring)
+				got := ma.Value()
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("after Add(%v): got %v, want %v (window=%v)", v, got, want, ring)
+				}
+			}
+		})
+	}
+}
+
+func TestMedianMovingAverageWindowClamp(t *testing.T) {
+	for _, window := range []int{0, -1} {
+		ma := NewMedianMovingAverage(window)
+		ma.Add(1)
+		ma.Add(2)
+		if got, want := ma.Value(), 2.0; got != want {
+			t.Errorf("window=%d: got %v, want %v", window, got, want)
+		}
+	}
+}
+
+func TestMedianMovingAverageSet(t *testing.T) {
+	ma := NewMedianMovingAverage(3)
+	ma.Add(1)
+	ma.Add(2)
+	ma.Add(3)
+	ma.Set(10)
+	if got, want := ma.Value(), 10.0; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	ma.Add(20)
+	if got, want := ma.Value(), 15.0; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package decor
+
+// onComplete is a mixin embedded by decorators that support a completion
+// message: either a static one set via OnCompleteMessage, or a dynamic
+// one computed from the final Statistics snapshot via
+// OnCompleteFormatter. movingAverageSpeed and averageSpeed embed it;
+// ETA and percentage decorators can embed the same mixin instead of
+// duplicating the static-message-only version of this logic.
+type onComplete struct {
+	msg       *string
+	formatter func(st *Statistics, final interface{}) string
+}
+
+// OnCompleteMessage sets a static message to render once the bar
+// completes, replacing whatever the decorator would otherwise show.
+func (o *onComplete) OnCompleteMessage(msg string) {
+	o.msg = &msg
+}
+
+// OnCompleteFormatter sets f to compute the completion message from the
+// Statistics snapshot and the decorator's last rendered value (for
+// example the last computed speed), instead of a static string. It
+// takes precedence over a message set via OnCompleteMessage.
+func (o *onComplete) OnCompleteFormatter(f func(st *Statistics, final interface{}) string) {
+	o.formatter = f
+}
+
+// onCompleter is implemented by decorators that embed onComplete, so
+// wrapper types composed over a Decorator interface value (which would
+// otherwise mask OnCompleteMessage/OnCompleteFormatter) can forward to
+// it via a type assertion instead of losing it.
+type onCompleter interface {
+	OnCompleteMessage(string)
+	OnCompleteFormatter(func(st *Statistics, final interface{}) string)
+}
+
+// complete returns the message to render for final and true, or "" and
+// false if neither OnCompleteMessage nor OnCompleteFormatter was set.
+func (o *onComplete) complete(st *Statistics, final interface{}) (string, bool) {
+	switch {
+	case o.formatter != nil:
+		return o.formatter(st, final), true
+	case o.msg != nil:
+		return *o.msg, true
+	default:
+		return "", false
+	}
+}
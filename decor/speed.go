@@ -11,8 +11,45 @@ import (
 
 const (
 	perSecond = "/s"
+	perMinute = "/min"
+	perHour   = "/h"
 )
 
+// RateUnit determines the time unit a speed decorator renders its value
+// against, and the suffix speedType appends to it. The zero value is
+// RatePerSecond, so existing callers default to today's behavior.
+type RateUnit int
+
+const (
+	RatePerSecond RateUnit = iota
+	RatePerMinute
+	RatePerHour
+)
+
+// seconds returns how many seconds make up one unit of r, which is what
+// a per-second sample needs to be multiplied by to read as a rate of r.
+func (r RateUnit) seconds() float64 {
+	switch r {
+	case RatePerMinute:
+		return 60
+	case RatePerHour:
+		return 3600
+	default:
+		return 1
+	}
+}
+
+func (r RateUnit) suffix() string {
+	switch r {
+	case RatePerMinute:
+		return perMinute
+	case RatePerHour:
+		return perHour
+	default:
+		return perSecond
+	}
+}
+
 type speedType struct {
 	sizeT     fmt.Formatter
 	perSecond string
@@ -23,10 +60,10 @@ func (self *speedType) Format(st fmt.State, verb rune) {
 	io.WriteString(st, self.perSecond)
 }
 
-func sizePerSecond(sizeT fmt.Formatter) fmt.Formatter {
+func sizeWithRate(sizeT fmt.Formatter, suffix string) fmt.Formatter {
 	return &speedType{
 		sizeT:     sizeT,
-		perSecond: perSecond,
+		perSecond: suffix,
 	}
 }
 
@@ -38,6 +75,28 @@ func EwmaSpeed(unit int, fmt string, age float64, wcc ...WC) Decorator {
 	return MovingAverageSpeed(unit, fmt, ewma.NewMovingAverage(age), wcc...)
 }
 
+// EwmaSpeedWithRate is like EwmaSpeed, but renders the average against
+// rate instead of assuming RatePerSecond.
+func EwmaSpeedWithRate(unit int, fmt string, age float64, rate RateUnit, wcc ...WC) Decorator {
+	return MovingAverageSpeedWithRate(unit, fmt, ewma.NewMovingAverage(age), rate, wcc...)
+}
+
+// MedianSpeed is a fixed-window median based speed decorator, backed by
+// NewMedianMovingAverage. It's more resistant to occasional outlying
+// samples than EwmaSpeed, at the cost of a bit more reporting lag. It's
+// a wrapper of MovingAverageSpeed.
+func MedianSpeed(unit int, fmt string, window int, wcc ...WC) Decorator {
+	return MovingAverageSpeed(unit, fmt, NewMedianMovingAverage(window), wcc...)
+}
+
+// NewVariableEwmaSpeed is a wrapper of MovingAverageSpeed, using a
+// NewVariableEWMA average so that irregular gaps between Incr* calls
+// (e.g. streaming with pauses) are weighted by real elapsed time rather
+// than a fixed sample age.
+func NewVariableEwmaSpeed(unit int, fmt string, halfLife time.Duration, wcc ...WC) Decorator {
+	return MovingAverageSpeed(unit, fmt, NewVariableEWMA(halfLife), wcc...)
+}
+
 // MovingAverageSpeed decorator relies on MovingAverage implementation
 // to calculate its average.
 //
@@ -57,6 +116,12 @@ func EwmaSpeed(unit int, fmt string, age float64, wcc ...WC) Decorator {
 //	unit=UnitKB,  fmt="% .1f" output: "1.0 MB/s"
 //
 func MovingAverageSpeed(unit int, fmt string, average MovingAverage, wcc ...WC) Decorator {
+	return MovingAverageSpeedWithRate(unit, fmt, average, RatePerSecond, wcc...)
+}
+
+// MovingAverageSpeedWithRate is like MovingAverageSpeed, but renders the
+// average against rate instead of assuming RatePerSecond.
+func MovingAverageSpeedWithRate(unit int, fmt string, average MovingAverage, rate RateUnit, wcc ...WC) Decorator {
 	var wc WC
 	for _, widthConf := range wcc {
 		wc = widthConf
@@ -70,34 +135,36 @@ func MovingAverageSpeed(unit int, fmt string, average MovingAverage, wcc ...WC)
 		unit:    unit,
 		fmt:     fmt,
 		average: average,
+		rate:    rate,
 	}
 	return d
 }
 
 type movingAverageSpeed struct {
 	WC
-	unit        int
-	fmt         string
-	average     ewma.MovingAverage
-	msg         string
-	completeMsg *string
+	onComplete
+	unit    int
+	fmt     string
+	average ewma.MovingAverage
+	rate    RateUnit
+	msg     string
 }
 
 func (d *movingAverageSpeed) Decor(st *Statistics) string {
 	if st.Completed {
-		if d.completeMsg != nil {
-			return d.FormatMsg(*d.completeMsg)
+		if msg, ok := d.complete(st, d.average.Value()*d.rate.seconds()); ok {
+			return d.FormatMsg(msg)
 		}
 		return d.FormatMsg(d.msg)
 	}
 
-	speed := d.average.Value()
+	speed := d.average.Value() * d.rate.seconds()
 
 	switch d.unit {
 	case UnitKiB:
-		d.msg = fmt.Sprintf(d.fmt, SizeB1024(math.Round(speed)))
+		d.msg = fmt.Sprintf(d.fmt, sizeWithRate(SizeB1024(math.Round(speed)), d.rate.suffix()))
 	case UnitKB:
-		d.msg = fmt.Sprintf(d.fmt, SizeB1000(math.Round(speed)))
+		d.msg = fmt.Sprintf(d.fmt, sizeWithRate(SizeB1000(math.Round(speed)), d.rate.suffix()))
 	default:
 		d.msg = fmt.Sprintf(d.fmt, speed)
 	}
@@ -106,19 +173,7 @@ func (d *movingAverageSpeed) Decor(st *Statistics) string {
 }
 
 func (d *movingAverageSpeed) NextAmount(n int64, wdd ...time.Duration) {
-	var workDuration time.Duration
-	for _, wd := range wdd {
-		workDuration = wd
-	}
-	speed := float64(n) / workDuration.Seconds() / 1000
-	if math.IsInf(speed, 0) || math.IsNaN(speed) {
-		return
-	}
-	d.average.Add(speed)
-}
-
-func (d *movingAverageSpeed) OnCompleteMessage(msg string) {
-	d.completeMsg = &msg
+	feedAverage(d.average, n, wdd...)
 }
 
 // AverageSpeed decorator with dynamic unit measure adjustment. It's
@@ -127,6 +182,27 @@ func AverageSpeed(unit int, fmt string, wcc ...WC) Decorator {
 	return NewAverageSpeed(unit, fmt, time.Now(), wcc...)
 }
 
+// NewAverageSpeedWithRate is like NewAverageSpeed, but renders the
+// average against rate instead of assuming RatePerSecond.
+func NewAverageSpeedWithRate(unit int, fmt string, startTime time.Time, rate RateUnit, wcc ...WC) Decorator {
+	var wc WC
+	for _, widthConf := range wcc {
+		wc = widthConf
+	}
+	wc.Init()
+	if fmt == "" {
+		fmt = "%.0f"
+	}
+	d := &averageSpeed{
+		WC:        wc,
+		unit:      unit,
+		startTime: startTime,
+		fmt:       fmt,
+		rate:      rate,
+	}
+	return d
+}
+
 // NewAverageSpeed decorator with dynamic unit measure adjustment and
 // user provided start time.
 //
@@ -146,48 +222,37 @@ func AverageSpeed(unit int, fmt string, wcc ...WC) Decorator {
 //	unit=UnitKB,  fmt="% .1f" output: "1.0 MB/s"
 //
 func NewAverageSpeed(unit int, fmt string, startTime time.Time, wcc ...WC) Decorator {
-	var wc WC
-	for _, widthConf := range wcc {
-		wc = widthConf
-	}
-	wc.Init()
-	if fmt == "" {
-		fmt = "%.0f"
-	}
-	d := &averageSpeed{
-		WC:        wc,
-		unit:      unit,
-		startTime: startTime,
-		fmt:       fmt,
-	}
-	return d
+	return NewAverageSpeedWithRate(unit, fmt, startTime, RatePerSecond, wcc...)
 }
 
 type averageSpeed struct {
 	WC
-	unit        int
-	startTime   time.Time
-	fmt         string
-	msg         string
-	completeMsg *string
+	onComplete
+	unit      int
+	startTime time.Time
+	fmt       string
+	rate      RateUnit
+	msg       string
+	speed     float64
 }
 
 func (d *averageSpeed) Decor(st *Statistics) string {
 	if st.Completed {
-		if d.completeMsg != nil {
-			return d.FormatMsg(*d.completeMsg)
+		if msg, ok := d.complete(st, d.speed); ok {
+			return d.FormatMsg(msg)
 		}
 		return d.FormatMsg(d.msg)
 	}
 
 	timeElapsed := time.Since(d.startTime)
-	speed := float64(st.Current) / timeElapsed.Seconds()
+	speed := float64(st.Current) / timeElapsed.Seconds() * d.rate.seconds()
+	d.speed = speed
 
 	switch d.unit {
 	case UnitKiB:
-		d.msg = fmt.Sprintf(d.fmt, sizePerSecond(SizeB1024(math.Round(speed))))
+		d.msg = fmt.Sprintf(d.fmt, sizeWithRate(SizeB1024(math.Round(speed)), d.rate.suffix()))
 	case UnitKB:
-		d.msg = fmt.Sprintf(d.fmt, sizePerSecond(SizeB1000(math.Round(speed))))
+		d.msg = fmt.Sprintf(d.fmt, sizeWithRate(SizeB1000(math.Round(speed)), d.rate.suffix()))
 	default:
 		d.msg = fmt.Sprintf(d.fmt, speed)
 	}
@@ -195,10 +260,6 @@ func (d *averageSpeed) Decor(st *Statistics) string {
 	return d.FormatMsg(d.msg)
 }
 
-func (d *averageSpeed) OnCompleteMessage(msg string) {
-	d.completeMsg = &msg
-}
-
 func (d *averageSpeed) AverageAdjust(startTime time.Time) {
 	d.startTime = startTime
 }
@@ -0,0 +1,93 @@
+package decor
+
+import "time"
+
+// DecorAmountReceiver is implemented by decorators that want to be fed
+// incremental amounts directly from a bar's Incr* calls, along with an
+// optional work duration. movingAverageSpeed implements it so
+// MovingAverageSpeed can feed its average; SpeedSource implements it so
+// several decorators can share one feed.
+type DecorAmountReceiver interface {
+	NextAmount(n int64, wdd ...time.Duration)
+}
+
+// SpeedSource owns a single MovingAverage and is itself the only thing
+// fed from Incr*: it must be appended as a decorator (it renders as
+// empty) alongside the Speed/ETA decorators it hands out below, so those
+// two always read the one average it feeds, instead of drifting apart
+// the way two independently-fed averages (e.g. EwmaSpeed plus EwmaETA)
+// would.
+//
+//	source := decor.NewSpeedSource(decor.NewMedianMovingAverage(30))
+//	bar := p.New(total,
+//		mpb.BarStyle(),
+//		mpb.AppendDecorators(
+//			source,
+//			source.Speed(decor.UnitKiB, "% .1f"),
+//			source.ETA(decor.ET_STYLE_GO),
+//		),
+//	)
+//
+// If Incr* is going to be called from more than one goroutine, wrap the
+// average with NewThreadSafeMovingAverage first.
+type SpeedSource struct {
+	WC
+	average MovingAverage
+}
+
+// NewSpeedSource returns a SpeedSource backed by average.
+func NewSpeedSource(average MovingAverage) *SpeedSource {
+	s := &SpeedSource{average: average}
+	s.Init()
+	return s
+}
+
+// Decor implements Decorator as an invisible placeholder. SpeedSource is
+// appended only so Incr* reaches its NextAmount; rendering happens
+// through the decorators returned by Speed and ETA.
+func (s *SpeedSource) Decor(*Statistics) string {
+	return s.FormatMsg("")
+}
+
+// NextAmount implements DecorAmountReceiver.
+func (s *SpeedSource) NextAmount(n int64, wdd ...time.Duration) {
+	feedAverage(s.average, n, wdd...)
+}
+
+// sourceDecorator re-exposes only the Decorator interface of the wrapped
+// decorator, masking any NextAmount method it implements so the bar
+// doesn't feed it directly — SpeedSource is the sole feed, fed once per
+// Incr*, and Speed/ETA below only ever read from it. OnCompleteMessage
+// and OnCompleteFormatter are forwarded explicitly below, since plain
+// embedding would mask those too.
+type sourceDecorator struct {
+	Decorator
+}
+
+// OnCompleteMessage forwards to the wrapped decorator if it embeds
+// onComplete.
+func (s sourceDecorator) OnCompleteMessage(msg string) {
+	if oc, ok := s.Decorator.(onCompleter); ok {
+		oc.OnCompleteMessage(msg)
+	}
+}
+
+// OnCompleteFormatter forwards to the wrapped decorator if it embeds
+// onComplete.
+func (s sourceDecorator) OnCompleteFormatter(f func(st *Statistics, final interface{}) string) {
+	if oc, ok := s.Decorator.(onCompleter); ok {
+		oc.OnCompleteFormatter(f)
+	}
+}
+
+// Speed returns a speed Decorator that reads from the shared average,
+// without feeding it.
+func (s *SpeedSource) Speed(unit int, fmt string, wcc ...WC) Decorator {
+	return sourceDecorator{MovingAverageSpeed(unit, fmt, s.average, wcc...)}
+}
+
+// ETA returns an ETA Decorator that reads from the shared average,
+// without feeding it.
+func (s *SpeedSource) ETA(style TimeStyle, wcc ...WC) Decorator {
+	return sourceDecorator{MovingAverageETA(style, s.average, wcc...)}
+}
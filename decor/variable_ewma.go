@@ -0,0 +1,58 @@
+package decor
+
+import (
+	"math"
+	"time"
+)
+
+// ln2 is used to convert a half-life into the decay constant of an
+// exponential.
+const ln2 = 0.693147180559945309417232121458176568
+
+// variableEWMA is a MovingAverage implementation whose decay adapts to
+// the actual wall-clock time between samples, rather than assuming a
+// fixed sample age like github.com/VividCortex/ewma does.
+type variableEWMA struct {
+	value       float64
+	lastAdd     time.Time
+	halfLife    float64
+	initialized bool
+}
+
+// NewVariableEWMA returns a MovingAverage that decays samples based on
+// the real elapsed time between them, using halfLife as the time it
+// takes a past sample's contribution to decay to half its original
+// weight.
+func NewVariableEWMA(halfLife time.Duration) MovingAverage {
+	return &variableEWMA{halfLife: halfLife.Seconds()}
+}
+
+func (v *variableEWMA) Add(sample float64) {
+	v.AddTime(sample, time.Now())
+}
+
+// AddTime feeds sample into the average as of now, instead of
+// time.Now(). It implements TimeMovingAverage so NextAmount can supply
+// the real timestamp of each Incr* call.
+func (v *variableEWMA) AddTime(sample float64, now time.Time) {
+	if !v.initialized {
+		v.value = sample
+		v.lastAdd = now
+		v.initialized = true
+		return
+	}
+	dt := now.Sub(v.lastAdd).Seconds()
+	alpha := 1 - math.Exp(-dt*ln2/v.halfLife)
+	v.value += alpha * (sample - v.value)
+	v.lastAdd = now
+}
+
+func (v *variableEWMA) Value() float64 {
+	return v.value
+}
+
+func (v *variableEWMA) Set(value float64) {
+	v.value = value
+	v.lastAdd = time.Time{}
+	v.initialized = false
+}